@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// cacheEntry pairs a cached value with its expiry for the in-memory backend.
+// Values are stored pre-serialized (as Cache.Set receives them) so Get
+// behaves identically across backends instead of depending on a naked
+// interface{} type assertion at the call site.
+type cacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemoryCache is a process-local cache with TTL expiry and LRU eviction,
+// used as the default CACHE_BACKEND.
+type MemoryCache struct {
+	mu       sync.Mutex
+	entries  map[string]*cacheEntry
+	order    []string
+	ttl      time.Duration
+	capacity int
+}
+
+func NewMemoryCache(ttl time.Duration, capacity int) *MemoryCache {
+	return &MemoryCache{
+		entries:  make(map[string]*cacheEntry),
+		ttl:      ttl,
+		capacity: capacity,
+	}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if c.ttl > 0 && time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		c.removeFromOrder(key)
+		return nil, false
+	}
+
+	c.touch(key)
+	return entry.value, true
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists && c.capacity > 0 && len(c.entries) >= c.capacity {
+		c.evictOldest()
+	}
+
+	c.entries[key] = &cacheEntry{value: value, expires: time.Now().Add(c.ttl)}
+	c.touch(key)
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+	c.removeFromOrder(key)
+}
+
+func (c *MemoryCache) touch(key string) {
+	c.removeFromOrder(key)
+	c.order = append(c.order, key)
+}
+
+func (c *MemoryCache) removeFromOrder(key string) {
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *MemoryCache) evictOldest() {
+	if len(c.order) == 0 {
+		return
+	}
+	oldest := c.order[0]
+	c.order = c.order[1:]
+	delete(c.entries, oldest)
+}
+
+// RedisCache stores values as JSON in Redis so they survive process
+// restarts and can be shared across instances.
+type RedisCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewRedisCache(addr string, ttl time.Duration) *RedisCache {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	return &RedisCache{client: client, ttl: ttl}
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+
+	return data, true
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte) {
+	c.client.Set(ctx, key, value, c.ttl)
+}
+
+func (c *RedisCache) Delete(ctx context.Context, key string) {
+	c.client.Del(ctx, key)
+}
+
+// Cache is the pluggable caching layer used by CachedDatabase. Backends
+// are selected via CACHE_BACKEND so the process can swap memory for Redis
+// without code changes. Values are opaque serialized bytes: callers are
+// responsible for their own (de)serialization, which keeps Get's
+// behavior identical across backends instead of relying on a naked
+// interface{} type assertion that only happened to work for MemoryCache.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte)
+	Delete(ctx context.Context, key string)
+}
+
+const defaultCacheTTL = 5 * time.Minute
+
+// NewCache builds the Cache backend configured via cfg.CacheBackend
+// (memory|redis). It defaults to memory when unset.
+func NewCache(cfg Config) (Cache, error) {
+	switch cfg.CacheBackend {
+	case "", "memory":
+		return NewMemoryCache(defaultCacheTTL, 1000), nil
+	case "redis":
+		return NewRedisCache(cfg.RedisAddr, defaultCacheTTL), nil
+	default:
+		return nil, fmt.Errorf("unknown CACHE_BACKEND %q", cfg.CacheBackend)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCachedDatabase_GetCacheUser_PasswordSurvivesCacheHit(t *testing.T) {
+	repo := NewInMemoryUserRepo()
+	cache := NewMemoryCache(defaultCacheTTL, 1000)
+	cachedDB := NewCachedDatabase(repo, cache)
+	ctx := context.Background()
+
+	if err := repo.CreateUser(ctx, User{Email: "jane@example.com", Password: "s3cret!", Name: "Jane", Age: 30}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	first, err := cachedDB.GetCacheUser(ctx, "jane@example.com")
+	if err != nil {
+		t.Fatalf("GetCacheUser (miss): %v", err)
+	}
+	if first.Password == "" {
+		t.Fatalf("expected a hashed password on the cache-miss read")
+	}
+
+	second, err := cachedDB.GetCacheUser(ctx, "jane@example.com")
+	if err != nil {
+		t.Fatalf("GetCacheUser (hit): %v", err)
+	}
+	if second.Password != first.Password {
+		t.Fatalf("got password %q on cache hit, want %q (must match the cache-miss read)", second.Password, first.Password)
+	}
+}
+
+func TestCachedDatabase_GetCacheAllUsers_PasswordSurvivesCacheHit(t *testing.T) {
+	repo := NewInMemoryUserRepo()
+	cache := NewMemoryCache(defaultCacheTTL, 1000)
+	cachedDB := NewCachedDatabase(repo, cache)
+	ctx := context.Background()
+
+	if err := repo.CreateUser(ctx, User{Email: "jane@example.com", Password: "s3cret!", Name: "Jane", Age: 30}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	if _, err := cachedDB.GetCacheAllUsers(ctx); err != nil {
+		t.Fatalf("GetCacheAllUsers (miss): %v", err)
+	}
+
+	hit, err := cachedDB.GetCacheAllUsers(ctx)
+	if err != nil {
+		t.Fatalf("GetCacheAllUsers (hit): %v", err)
+	}
+	if len(hit) != 1 || hit[0].Password == "" {
+		t.Fatalf("expected the cache-hit read to still carry a hashed password, got %+v", hit)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config holds the service's runtime settings. Fields can be set in
+// config.toml and overridden by environment variables, following the
+// same "--config with env overrides" convention as woodpecker/drone.
+type Config struct {
+	Port         string `toml:"port"`
+	StorageDSN   string `toml:"storage_dsn"`
+	CacheBackend string `toml:"cache_backend"`
+	RedisAddr    string `toml:"redis_addr"`
+	JWTSecret    string `toml:"jwt_secret"`
+	AdminEmail   string `toml:"admin_email"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Port:         "8080",
+		StorageDSN:   "sqlite3://users.db",
+		CacheBackend: "memory",
+		RedisAddr:    "localhost:6379",
+		JWTSecret:    "dev-secret-change-me",
+	}
+}
+
+// LoadConfig reads path (if present) into a Config seeded with defaults,
+// then lets environment variables take precedence over the file so
+// deployments can override individual settings without editing it.
+func LoadConfig(path string) (Config, error) {
+	cfg := defaultConfig()
+
+	if _, err := os.Stat(path); err == nil {
+		if _, err := toml.DecodeFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	cfg.Port = envOrDefault("PORT", cfg.Port)
+	cfg.StorageDSN = envOrDefault("STORAGE_DSN", cfg.StorageDSN)
+	cfg.CacheBackend = envOrDefault("CACHE_BACKEND", cfg.CacheBackend)
+	cfg.RedisAddr = envOrDefault("REDIS_ADDR", cfg.RedisAddr)
+	cfg.JWTSecret = envOrDefault("JWT_SECRET", cfg.JWTSecret)
+	cfg.AdminEmail = envOrDefault("ADMIN_EMAIL", cfg.AdminEmail)
+
+	jwtSecret = []byte(cfg.JWTSecret)
+
+	return cfg, nil
+}
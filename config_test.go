@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), "missing.toml"))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	want := defaultConfig()
+	if cfg != want {
+		t.Fatalf("got %+v, want defaults %+v", cfg, want)
+	}
+}
+
+func TestLoadConfig_FileOverridesDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	toml := "port = \"9090\"\nadmin_email = \"root@example.com\"\n"
+	if err := os.WriteFile(path, []byte(toml), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	if cfg.Port != "9090" {
+		t.Fatalf("got port %q, want 9090", cfg.Port)
+	}
+	if cfg.AdminEmail != "root@example.com" {
+		t.Fatalf("got admin email %q, want root@example.com", cfg.AdminEmail)
+	}
+	if cfg.CacheBackend != defaultConfig().CacheBackend {
+		t.Fatalf("unset fields should keep their default, got cache backend %q", cfg.CacheBackend)
+	}
+}
+
+func TestLoadConfig_EnvOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("port = \"9090\"\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	t.Setenv("PORT", "7070")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Port != "7070" {
+		t.Fatalf("got port %q, want env override 7070", cfg.Port)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+// newTestHandler wires a UserHandler against an in-memory repo/cache, the
+// same combination repoBackends uses for storage tests.
+func newTestHandler(t *testing.T) *UserHandler {
+	t.Helper()
+
+	repo := NewInMemoryUserRepo()
+	cache := NewMemoryCache(defaultCacheTTL, 1000)
+	serv := NewUserService(repo, cache)
+	cachedDB := NewCachedDatabase(repo, cache)
+
+	return NewUserHandler(serv, cachedDB, "")
+}
+
+// requestWithEmailParam builds a request carrying the caller's claims (as
+// JWTAuth would have stashed them) and a chi {email} route param,
+// mirroring how the real router calls GetByEmailHand/UpdateHand/DeleteHand.
+func requestWithEmailParam(t *testing.T, method, email string, caller string, role Role) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequest(method, "/users/"+email, nil)
+
+	ctx := contextWithClaims(req.Context(), &Claims{Email: caller, Role: role})
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("email", email)
+	ctx = context.WithValue(ctx, chi.RouteCtxKey, rctx)
+
+	return req.WithContext(ctx)
+}
+
+func TestUserHandler_GetByEmailHand_OwnerVsAdminVsOther(t *testing.T) {
+	h := newTestHandler(t)
+	if err := h.UserServ.CreateUser(context.Background(), User{Email: "jane@example.com", Password: "s3cret!", Name: "Jane", Age: 30}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		caller     string
+		role       Role
+		wantStatus int
+	}{
+		{"owner", "jane@example.com", RoleUser, http.StatusOK},
+		{"admin", "admin@example.com", RoleAdmin, http.StatusOK},
+		{"other user", "mallory@example.com", RoleUser, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := requestWithEmailParam(t, http.MethodGet, "jane@example.com", tt.caller, tt.role)
+			rec := httptest.NewRecorder()
+
+			h.GetByEmailHand(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestUserHandler_DeleteHand_OwnerVsAdminVsOther(t *testing.T) {
+	tests := []struct {
+		name       string
+		caller     string
+		role       Role
+		wantStatus int
+	}{
+		{"owner", "jane@example.com", RoleUser, http.StatusNoContent},
+		{"other user", "mallory@example.com", RoleUser, http.StatusForbidden},
+		{"admin", "admin@example.com", RoleAdmin, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Fresh handler per case: a forbidden delete must leave the
+			// account in place, so each case starts from a clean slate
+			// rather than depending on the previous case's outcome.
+			h := newTestHandler(t)
+			if err := h.UserServ.CreateUser(context.Background(), User{Email: "jane@example.com", Password: "s3cret!", Name: "Jane", Age: 30}); err != nil {
+				t.Fatalf("CreateUser: %v", err)
+			}
+
+			req := requestWithEmailParam(t, http.MethodDelete, "jane@example.com", tt.caller, tt.role)
+			rec := httptest.NewRecorder()
+
+			h.DeleteHand(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestUserHandler_CreateHand_PasswordStrength(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		wantStatus int
+	}{
+		{"too short", "Abc1234", http.StatusBadRequest},
+		{"long but no uppercase or digit", "aaaaaaaaaaaa", http.StatusBadRequest},
+		{"long with digit but no uppercase", "abcdefgh1", http.StatusBadRequest},
+		{"meets strength requirements", "Abcdefg1", http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t)
+
+			body, err := json.Marshal(map[string]any{
+				"email":    "jane@example.com",
+				"password": tt.password,
+				"name":     "Jane",
+				"age":      30,
+			})
+			if err != nil {
+				t.Fatalf("marshal request: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			h.CreateHand(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestUserHandler_GetHand_RequiresAdmin(t *testing.T) {
+	h := newTestHandler(t)
+	if err := h.UserServ.CreateUser(context.Background(), User{Email: "jane@example.com", Password: "s3cret!", Name: "Jane", Age: 30}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	// GetHand has no per-resource owner, so the router only ever reaches
+	// it behind JWTAuth+Authorize(RoleAdmin); exercise that same chain.
+	chain := JWTAuth(Authorize(RoleAdmin)(http.HandlerFunc(h.GetHand)))
+
+	tests := []struct {
+		name       string
+		role       Role
+		wantStatus int
+	}{
+		{"admin allowed", RoleAdmin, http.StatusOK},
+		{"regular user rejected", RoleUser, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set("Authorization", "Bearer "+tokenFor(t, "someone@example.com", tt.role))
+			rec := httptest.NewRecorder()
+
+			chain.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// zlog is the process-wide structured logger used for request logging.
+var zlog = zerolog.New(os.Stdout).With().Timestamp().Logger()
+
+type requestIDKey struct{}
+
+// RequestID assigns a fresh request ID (or reuses an inbound one) and
+// both propagates it via the X-Request-ID header and stashes it in the
+// request context for logging.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestLogger emits one structured log line per request with the
+// method, path, status, latency and request ID.
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		zlog.Info().
+			Str("method", r.Method).
+			Str("path", chiRoutePattern(r)).
+			Int("status", rec.status).
+			Dur("latency", time.Since(start)).
+			Str("request_id", requestIDFromContext(r.Context())).
+			Msg("http request")
+	})
+}
@@ -1,27 +1,78 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
-	"sync"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/go-playground/validator/v10"
 )
 
+var validate = validator.New()
+
+// User is the internal representation persisted to storage and cache.
+// It is never serialized directly to HTTP clients; see userRequest and
+// UserResponse for the request/response shapes at the API boundary.
 type User struct {
 	Email    string `json:"email"`
-	Password string `json:"password"`
+	Password string `json:"-"`
 	Name     string `json:"name"`
 	Age      int    `json:"age"`
+	Role     Role   `json:"role"`
+}
+
+// userRequest is what POST/PUT /users decodes from the client. It
+// deliberately has no Role field: role is set server-side on creation
+// and can only be changed through a separate admin-only endpoint, so a
+// caller can never self-grant privileges through this payload.
+type userRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	// Password strength: at least 8 characters, with at least one
+	// uppercase letter and one digit, not just a length floor.
+	Password string `json:"password" validate:"required,min=8,containsany=ABCDEFGHIJKLMNOPQRSTUVWXYZ,containsany=0123456789"`
+	Name     string `json:"name" validate:"required"`
+	Age      int    `json:"age" validate:"gte=18"`
+}
+
+// UserResponse is what every /users handler serializes back. It omits
+// Password so bcrypt hashes (and certainly never plaintext) are never
+// echoed to clients.
+type UserResponse struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	Age   int    `json:"age"`
+	Role  Role   `json:"role"`
+}
+
+func toUserResponse(u User) UserResponse {
+	return UserResponse{Email: u.Email, Name: u.Name, Age: u.Age, Role: u.Role}
+}
+
+func toUserResponses(users []User) []UserResponse {
+	resp := make([]UserResponse, len(users))
+	for i, u := range users {
+		resp[i] = toUserResponse(u)
+	}
+	return resp
 }
 
 type UserRepository interface {
-	CreateUser(user User) error
-	GetAllUsers() ([]User, error)
+	CreateUser(ctx context.Context, user User) error
+	GetAllUsers(ctx context.Context) ([]User, error)
+	GetByEmail(ctx context.Context, email string) (User, error)
+	UpdatePassword(ctx context.Context, email, hashedPassword string) error
+	UpdateUser(ctx context.Context, user User) error
+	DeleteUser(ctx context.Context, email string) error
+	ListPaginated(ctx context.Context, limit, offset int) ([]User, error)
 }
 
 type UserRepo struct {
@@ -32,29 +83,12 @@ func NewUserRepo(db *sql.DB) *UserRepo {
 	return &UserRepo{db: db}
 }
 
-func CreateTable(db *sql.DB) error {
-
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS usersr (
-			email TEXT PRIMARY KEY,
-			password TEXT,
-			name TEXT,
-			age INTEGER
-		);
-	`)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (s *UserRepo) CreateUser(user User) error {
+func (s *UserRepo) CreateUser(ctx context.Context, user User) error {
 
 	var count int
-	err := s.db.QueryRow(`
+	err := s.db.QueryRowContext(ctx, `
 		SELECT COUNT(*) FROM usersr
-		WHERE email = ? 
+		WHERE email = ?
 	`, user.Email).Scan(&count)
 	if err != nil {
 		return err
@@ -64,20 +98,25 @@ func (s *UserRepo) CreateUser(user User) error {
 		return fmt.Errorf("user with the %s email already exists", user.Email)
 	}
 
-	if user.Age < 18 {
-		return fmt.Errorf("user must be at least 18 years old")
+	hashed, err := hashPassword(user.Password)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	if user.Role == "" {
+		user.Role = RoleUser
 	}
 
-	_, err = s.db.Exec(`
-		INSERT INTO usersr (email, password, name, age)
-		VALUES (?, ?, ?, ?)
-	`, user.Email, user.Password, user.Name, user.Age)
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO usersr (email, password, name, age, role)
+		VALUES (?, ?, ?, ?, ?)
+	`, user.Email, hashed, user.Name, user.Age, user.Role)
 
 	return err
 }
 
-func (s *UserRepo) GetAllUsers() ([]User, error) {
-	rows, err := s.db.Query(`SELECT email, password, name, age FROM usersr`)
+func (s *UserRepo) GetAllUsers(ctx context.Context) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT email, password, name, age, role FROM usersr`)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +125,7 @@ func (s *UserRepo) GetAllUsers() ([]User, error) {
 	var users []User
 	for rows.Next() {
 		var user User
-		err := rows.Scan(&user.Email, &user.Password, &user.Name, &user.Age)
+		err := rows.Scan(&user.Email, &user.Password, &user.Name, &user.Age, &user.Role)
 		if err != nil {
 			return nil, err
 		}
@@ -96,135 +135,474 @@ func (s *UserRepo) GetAllUsers() ([]User, error) {
 	return users, nil
 }
 
+func (s *UserRepo) GetByEmail(ctx context.Context, email string) (User, error) {
+	var user User
+	err := s.db.QueryRowContext(ctx, `
+		SELECT email, password, name, age, role FROM usersr WHERE email = ?
+	`, email).Scan(&user.Email, &user.Password, &user.Name, &user.Age, &user.Role)
+	if err != nil {
+		return User{}, fmt.Errorf("user with the %s email not found", email)
+	}
+
+	return user, nil
+}
+
+func (s *UserRepo) UpdatePassword(ctx context.Context, email, hashedPassword string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE usersr SET password = ? WHERE email = ?
+	`, hashedPassword, email)
+
+	return err
+}
+
+func (s *UserRepo) UpdateUser(ctx context.Context, user User) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE usersr SET name = ?, age = ?, role = ? WHERE email = ?
+	`, user.Name, user.Age, user.Role, user.Email)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(res, user.Email)
+}
+
+func (s *UserRepo) DeleteUser(ctx context.Context, email string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM usersr WHERE email = ?`, email)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(res, email)
+}
+
+func (s *UserRepo) ListPaginated(ctx context.Context, limit, offset int) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT email, password, name, age, role FROM usersr
+		ORDER BY email
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.Email, &user.Password, &user.Name, &user.Age, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func checkRowsAffected(res sql.Result, email string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("user with the %s email not found", email)
+	}
+	return nil
+}
+
 type UserServ interface {
-	CreateUser(user User) error
-	GetAllUsers() ([]User, error)
+	CreateUser(ctx context.Context, user User) error
+	GetAllUsers(ctx context.Context) ([]User, error)
+	GetByEmail(ctx context.Context, email string) (User, error)
+	RehashPassword(ctx context.Context, email, hashedPassword string) error
+	UpdateUser(ctx context.Context, user User) error
+	DeleteUser(ctx context.Context, email string) error
+	ListPaginated(ctx context.Context, limit, offset int) ([]User, error)
 }
 
 type UserService struct {
-	RepoUser *UserRepo
+	RepoUser UserRepository
+	Cache    Cache
 }
 
-func NewUserService(db *UserRepo) *UserService {
-	return &UserService{db}
+func NewUserService(db UserRepository, cache Cache) *UserService {
+	return &UserService{RepoUser: db, Cache: cache}
 }
 
-func (u *UserService) CreateUser(user User) error {
+func (u *UserService) CreateUser(ctx context.Context, user User) error {
+	if err := u.RepoUser.CreateUser(ctx, user); err != nil {
+		return err
+	}
+
+	u.Cache.Delete(ctx, "all_users")
+	u.Cache.Delete(ctx, "user:"+user.Email)
+
+	return nil
+}
+
+func (u *UserService) GetAllUsers(ctx context.Context) ([]User, error) {
+	return u.RepoUser.GetAllUsers(ctx)
+}
+
+func (u *UserService) GetByEmail(ctx context.Context, email string) (User, error) {
+	return u.RepoUser.GetByEmail(ctx, email)
+}
+
+// RehashPassword stores a freshly-hashed password for email, used to
+// migrate legacy plaintext rows the first time they're used to log in.
+func (u *UserService) RehashPassword(ctx context.Context, email, hashedPassword string) error {
+	return u.RepoUser.UpdatePassword(ctx, email, hashedPassword)
+}
+
+func (u *UserService) UpdateUser(ctx context.Context, user User) error {
+	if err := u.RepoUser.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+
+	u.Cache.Delete(ctx, "all_users")
+	u.Cache.Delete(ctx, "user:"+user.Email)
 
-	return u.RepoUser.CreateUser(user)
+	return nil
 }
 
-func (u *UserService) GetAllUsers() ([]User, error) {
-	return u.RepoUser.GetAllUsers()
+func (u *UserService) DeleteUser(ctx context.Context, email string) error {
+	if err := u.RepoUser.DeleteUser(ctx, email); err != nil {
+		return err
+	}
+
+	u.Cache.Delete(ctx, "all_users")
+	u.Cache.Delete(ctx, "user:"+email)
+
+	return nil
 }
 
-type Cache interface {
-	Get(key string) (interface{}, bool)
-	Set(key string, value interface{})
-	GetCacheAllUsers() ([]User, error)
+func (u *UserService) ListPaginated(ctx context.Context, limit, offset int) ([]User, error) {
+	return u.RepoUser.ListPaginated(ctx, limit, offset)
 }
 
 type CachedDatabase struct {
-	Database *UserRepo
-	cache    map[string]interface{}
-	mu       sync.RWMutex
+	Database UserRepository
+	cache    Cache
 }
 
-func NewCachedDatabase(db *UserRepo) *CachedDatabase {
+func NewCachedDatabase(db UserRepository, cache Cache) *CachedDatabase {
 	return &CachedDatabase{
 		Database: db,
-		cache:    make(map[string]interface{}),
+		cache:    cache,
 	}
 }
 
-func (c *CachedDatabase) Get(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	value, ok := c.cache[key]
-	return value, ok
+// cacheUser is the cache layer's own serialization of a User. It mirrors
+// every DB field, including Password: User's json tags are tuned for HTTP
+// responses (Password is "-" so it never leaks to clients), and reusing
+// User directly here would silently zero Password out on every cache hit.
+type cacheUser struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+	Age      int    `json:"age"`
+	Role     Role   `json:"role"`
+}
+
+func toCacheUser(u User) cacheUser {
+	return cacheUser{Email: u.Email, Password: u.Password, Name: u.Name, Age: u.Age, Role: u.Role}
 }
 
-func (c *CachedDatabase) Set(key string, value interface{}) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.cache[key] = value
+func (c cacheUser) toUser() User {
+	return User{Email: c.Email, Password: c.Password, Name: c.Name, Age: c.Age, Role: c.Role}
 }
 
-func (c *CachedDatabase) GetCacheAllUsers() ([]User, error) {
-	if value, ok := c.Get("all_users"); ok {
-		if users, ok := value.([]User); ok {
-			fmt.Println("Users from cache")
+func (c *CachedDatabase) GetCacheAllUsers(ctx context.Context) ([]User, error) {
+	if data, ok := c.cache.Get(ctx, "all_users"); ok {
+		var cached []cacheUser
+		if err := json.Unmarshal(data, &cached); err == nil {
+			cacheHitsTotal.Inc()
+			users := make([]User, len(cached))
+			for i, cu := range cached {
+				users[i] = cu.toUser()
+			}
 			return users, nil
 		}
 	}
+	cacheMissesTotal.Inc()
 
-	users, err := c.Database.GetAllUsers()
+	users, err := c.Database.GetAllUsers(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	c.Set("all_users", users)
+	cached := make([]cacheUser, len(users))
+	for i, u := range users {
+		cached[i] = toCacheUser(u)
+	}
+	if data, err := json.Marshal(cached); err == nil {
+		c.cache.Set(ctx, "all_users", data)
+	}
 
 	return users, nil
 }
 
+// GetCacheUser is the per-user counterpart to GetCacheAllUsers, cached
+// under "user:"+email so the UserService.*User writes that already
+// invalidate that key actually have something to invalidate.
+func (c *CachedDatabase) GetCacheUser(ctx context.Context, email string) (User, error) {
+	key := "user:" + email
+
+	if data, ok := c.cache.Get(ctx, key); ok {
+		var cached cacheUser
+		if err := json.Unmarshal(data, &cached); err == nil {
+			cacheHitsTotal.Inc()
+			return cached.toUser(), nil
+		}
+	}
+	cacheMissesTotal.Inc()
+
+	user, err := c.Database.GetByEmail(ctx, email)
+	if err != nil {
+		return User{}, err
+	}
+
+	if data, err := json.Marshal(toCacheUser(user)); err == nil {
+		c.cache.Set(ctx, key, data)
+	}
+
+	return user, nil
+}
+
 type UserHandler struct {
-	UserServ *UserService
-	CacheDB  *CachedDatabase
+	UserServ   *UserService
+	CacheDB    *CachedDatabase
+	AdminEmail string
 }
 
-func NewUserHandler(uServ *UserService, cache *CachedDatabase) *UserHandler {
-	return &UserHandler{uServ, cache}
+func NewUserHandler(uServ *UserService, cache *CachedDatabase, adminEmail string) *UserHandler {
+	return &UserHandler{uServ, cache, adminEmail}
 }
 
 func (h *UserHandler) CreateHand(w http.ResponseWriter, r *http.Request) {
+	var req userRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	user := User{
-		Email:    "Аpopo",
-		Password: "sdgsg",
-		Name:     "gsfdgsd",
-		Age:      20,
+	// The deployment's ADMIN_EMAIL bootstraps the first admin account:
+	// without it, nothing ever assigns RoleAdmin and the admin-only
+	// routes would be permanently unreachable. Every other registration
+	// still gets RoleUser, so this can't be used to self-grant privileges.
+	role := RoleUser
+	if h.AdminEmail != "" && req.Email == h.AdminEmail {
+		role = RoleAdmin
 	}
 
-	if err := h.UserServ.CreateUser(user); err != nil {
+	user := User{Email: req.Email, Password: req.Password, Name: req.Name, Age: req.Age, Role: role}
+
+	if err := h.UserServ.CreateUser(r.Context(), user); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	w.Write([]byte("Create user"))
+
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toUserResponse(user))
 }
 
 func (h *UserHandler) GetHand(w http.ResponseWriter, r *http.Request) {
-	users, err := h.CacheDB.GetCacheAllUsers()
+	q := r.URL.Query()
+	if !q.Has("limit") && !q.Has("offset") {
+		users, err := h.CacheDB.GetCacheAllUsers(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(toUserResponses(users))
+		return
+	}
+
+	limit := atoiOrDefault(q.Get("limit"), 20)
+	offset := atoiOrDefault(q.Get("offset"), 0)
+
+	users, err := h.UserServ.ListPaginated(r.Context(), limit, offset)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(users); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(toUserResponses(users))
+}
+
+// ownerOrAdmin reports whether the request's JWT claims belong to email
+// itself or to an admin, the same access rule GET /users/{email} already
+// enforces. PUT/DELETE /users/{email} reuse it so owners can manage their
+// own account without needing RoleAdmin.
+func ownerOrAdmin(ctx context.Context, email string) bool {
+	claims, ok := claimsFromContext(ctx)
+	return ok && (claims.Email == email || claims.Role == RoleAdmin)
+}
+
+func (h *UserHandler) GetByEmailHand(w http.ResponseWriter, r *http.Request) {
+	email := chi.URLParam(r, "email")
+
+	if !ownerOrAdmin(r.Context(), email) {
+		http.Error(w, "forbidden", http.StatusForbidden)
 		return
 	}
+
+	user, err := h.CacheDB.GetCacheUser(r.Context(), email)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(toUserResponse(user))
+}
+
+func (h *UserHandler) UpdateHand(w http.ResponseWriter, r *http.Request) {
+	email := chi.URLParam(r, "email")
+
+	if !ownerOrAdmin(r.Context(), email) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var req userRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Email = email
+
+	if err := validate.StructExcept(req, "Password"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Role is intentionally left untouched here: it comes from the
+	// existing record, not the request body, so this endpoint can never
+	// be used to change a user's privileges.
+	existing, err := h.UserServ.GetByEmail(r.Context(), email)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	user := User{Email: email, Password: existing.Password, Name: req.Name, Age: req.Age, Role: existing.Role}
+
+	if err := h.UserServ.UpdateUser(r.Context(), user); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(toUserResponse(user))
+}
+
+func (h *UserHandler) DeleteHand(w http.ResponseWriter, r *http.Request) {
+	email := chi.URLParam(r, "email")
+
+	if !ownerOrAdmin(r.Context(), email) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	if err := h.UserServ.DeleteUser(r.Context(), email); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func atoiOrDefault(s string, def int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return def
+	}
+	return n
 }
 
 func main() {
+	cfg, err := LoadConfig(envOrDefault("CONFIG_FILE", "config.toml"))
+	if err != nil {
+		zlog.Fatal().Err(err).Msg("load config")
+	}
+
 	r := chi.NewRouter()
-	db, err := sql.Open("sqlite3", "users.db")
+	r.Use(RequestID)
+	r.Use(RequestLogger)
+	r.Use(MetricsMiddleware)
+
+	usRep, err := OpenStorage(cfg.StorageDSN)
 	if err != nil {
-		log.Fatal(err)
+		zlog.Fatal().Err(err).Msg("open storage")
 	}
-	usRep := NewUserRepo(db)
-	usServ := NewUserService(usRep)
-	cachedDB := NewCachedDatabase(usRep)
-	usHand := NewUserHandler(usServ, cachedDB)
-	err = CreateTable(db)
+	usRep = NewInstrumentedUserRepo(usRep)
+
+	cache, err := NewCache(cfg)
 	if err != nil {
-		log.Fatal(err)
+		zlog.Fatal().Err(err).Msg("open cache")
 	}
 
-	r.Get("/create", usHand.CreateHand)
+	usServ := NewUserService(usRep, cache)
+	cachedDB := NewCachedDatabase(usRep, cache)
+	usHand := NewUserHandler(usServ, cachedDB, cfg.AdminEmail)
+	authHand := NewAuthHandler(usServ)
+
+	r.Route("/users", func(r chi.Router) {
+		// Registration is intentionally open: CreateHand only assigns
+		// RoleAdmin to cfg.AdminEmail, so this can't be used to
+		// self-grant privileges, and it's how the first (admin) account
+		// gets created in a fresh deployment.
+		r.Post("/", usHand.CreateHand)
+
+		r.Group(func(r chi.Router) {
+			r.Use(JWTAuth)
+
+			// GetByEmailHand, UpdateHand and DeleteHand each check
+			// ownerOrAdmin themselves, since "owner" depends on the
+			// {email} path param and can't be expressed as a role-only
+			// middleware.
+			r.Get("/{email}", usHand.GetByEmailHand)
+			r.Put("/{email}", usHand.UpdateHand)
+			r.Delete("/{email}", usHand.DeleteHand)
+
+			r.Group(func(r chi.Router) {
+				r.Use(Authorize(RoleAdmin))
+
+				// GetHand (GET /users) returns every account's PII in one
+				// call, including who holds RoleAdmin, so it needs the same
+				// access-control intent GetByEmailHand enforces per-user
+				// but restricted to admins since there's no single owner.
+				r.Get("/", usHand.GetHand)
+			})
+		})
+	})
+
+	r.Post("/login", authHand.LoginHand)
+
+	r.Handle("/metrics", MetricsHandler())
+
+	srv := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: r,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			zlog.Fatal().Err(err).Msg("listen and serve")
+		}
+	}()
 
-	r.Get("/users", usHand.GetHand)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
 
-	log.Fatal(http.ListenAndServe(":8080", r))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		zlog.Error().Err(err).Msg("graceful shutdown failed")
+	}
 }
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// chiRoutePattern returns the routed pattern (e.g. "/users/{email}")
+// rather than the raw URL, falling back to the literal path for request
+// logging where an arbitrary path is useful for debugging. Metrics must
+// NOT use that fallback (see metricsRoutePath): it reintroduces unbounded
+// label cardinality for the exact case this function exists to avoid.
+func chiRoutePattern(r *http.Request) string {
+	if ctx := chi.RouteContext(r.Context()); ctx != nil && ctx.RoutePattern() != "" {
+		return ctx.RoutePattern()
+	}
+	return r.URL.Path
+}
+
+// unmatchedRouteLabel is the fixed metrics label used in place of the raw
+// URL for requests that never matched a route. MetricsMiddleware runs
+// before auth, so an anonymous client hitting random paths must not be
+// able to grow Prometheus label cardinality.
+const unmatchedRouteLabel = "unmatched"
+
+func metricsRoutePath(r *http.Request) string {
+	if ctx := chi.RouteContext(r.Context()); ctx != nil && ctx.RoutePattern() != "" {
+		return ctx.RoutePattern()
+	}
+	return unmatchedRouteLabel
+}
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total HTTP requests processed, labeled by method, path and status.",
+	}, []string{"method", "path", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "http_request_duration_seconds",
+		Help: "HTTP request latency in seconds.",
+	}, []string{"method", "path"})
+
+	dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "db_query_duration_seconds",
+		Help: "UserRepository query latency in seconds, labeled by method.",
+	}, []string{"method"})
+
+	cacheHitsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cache_hits_total",
+		Help: "Number of cache lookups served from cache.",
+	})
+
+	cacheMissesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cache_misses_total",
+		Help: "Number of cache lookups that fell through to the database.",
+	})
+)
+
+// MetricsHandler exposes the Prometheus scrape endpoint.
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
+
+// responseRecorder captures the status code written by downstream
+// handlers so the metrics/logging middleware can report it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// MetricsMiddleware records request counts and latency per method/path.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		path := metricsRoutePath(r)
+		httpRequestDuration.WithLabelValues(r.Method, path).Observe(time.Since(start).Seconds())
+		httpRequestsTotal.WithLabelValues(r.Method, path, http.StatusText(rec.status)).Inc()
+	})
+}
+
+// InstrumentedUserRepo wraps a UserRepository and records query latency
+// for every call, so operators can see where time in the DB layer goes.
+type InstrumentedUserRepo struct {
+	UserRepository
+}
+
+func NewInstrumentedUserRepo(repo UserRepository) *InstrumentedUserRepo {
+	return &InstrumentedUserRepo{repo}
+}
+
+func (i *InstrumentedUserRepo) CreateUser(ctx context.Context, user User) error {
+	defer observeDBQuery("CreateUser", time.Now())
+	return i.UserRepository.CreateUser(ctx, user)
+}
+
+func (i *InstrumentedUserRepo) GetAllUsers(ctx context.Context) ([]User, error) {
+	defer observeDBQuery("GetAllUsers", time.Now())
+	return i.UserRepository.GetAllUsers(ctx)
+}
+
+func (i *InstrumentedUserRepo) GetByEmail(ctx context.Context, email string) (User, error) {
+	defer observeDBQuery("GetByEmail", time.Now())
+	return i.UserRepository.GetByEmail(ctx, email)
+}
+
+func (i *InstrumentedUserRepo) UpdatePassword(ctx context.Context, email, hashedPassword string) error {
+	defer observeDBQuery("UpdatePassword", time.Now())
+	return i.UserRepository.UpdatePassword(ctx, email, hashedPassword)
+}
+
+func (i *InstrumentedUserRepo) UpdateUser(ctx context.Context, user User) error {
+	defer observeDBQuery("UpdateUser", time.Now())
+	return i.UserRepository.UpdateUser(ctx, user)
+}
+
+func (i *InstrumentedUserRepo) DeleteUser(ctx context.Context, email string) error {
+	defer observeDBQuery("DeleteUser", time.Now())
+	return i.UserRepository.DeleteUser(ctx, email)
+}
+
+func (i *InstrumentedUserRepo) ListPaginated(ctx context.Context, limit, offset int) ([]User, error) {
+	defer observeDBQuery("ListPaginated", time.Now())
+	return i.UserRepository.ListPaginated(ctx, limit, offset)
+}
+
+func observeDBQuery(method string, start time.Time) {
+	dbQueryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
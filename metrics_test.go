@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+func TestChiRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	var got string
+	r.Get("/users/{email}", func(w http.ResponseWriter, r *http.Request) {
+		got = chiRoutePattern(r)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/jane@example.com", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got != "/users/{email}" {
+		t.Fatalf("got route pattern %q, want /users/{email}", got)
+	}
+}
+
+func TestChiRoutePattern_FallsBackToRawPath(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/unrouted", nil)
+
+	if got := chiRoutePattern(req); got != "/unrouted" {
+		t.Fatalf("got %q, want raw path /unrouted", got)
+	}
+}
+
+func TestMetricsRoutePath(t *testing.T) {
+	r := chi.NewRouter()
+	var got string
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			got = metricsRoutePath(r)
+		})
+	})
+	r.Get("/users/{email}", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"matched route", "/users/jane@example.com", "/users/{email}"},
+		// An unmatched request must collapse to a fixed label, not the raw
+		// path: MetricsMiddleware runs before auth, so an anonymous client
+		// hitting arbitrary paths must not grow label cardinality.
+		{"unmatched route", "/totally/bogus/path", unmatchedRouteLabel},
+		{"another unmatched route", "/another/bogus/path", unmatchedRouteLabel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Fatalf("got metrics path %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetricsMiddleware_PassesThroughStatusAndBody(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("ok"))
+	})
+
+	rec := httptest.NewRecorder()
+	MetricsMiddleware(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusTeapot)
+	}
+	if rec.Body.String() != "ok" {
+		t.Fatalf("got body %q, want ok", rec.Body.String())
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// repoBackends returns the set of UserRepository backends to run the
+// shared suite against. Postgres only participates when STORAGE_TEST_DSN
+// points at a reachable instance, so CI without a database still passes.
+func repoBackends(t *testing.T) map[string]UserRepository {
+	t.Helper()
+
+	repos := map[string]UserRepository{
+		"memory": NewInMemoryUserRepo(),
+	}
+
+	sqliteRepo, err := OpenStorage("sqlite3://" + filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatalf("open sqlite3 storage: %v", err)
+	}
+	repos["sqlite3"] = sqliteRepo
+
+	dsn := os.Getenv("STORAGE_TEST_DSN")
+	if dsn == "" {
+		t.Log("STORAGE_TEST_DSN not set, skipping the postgres backend in this suite; CI must set it against a real Postgres instance")
+		return repos
+	}
+
+	pgRepo, err := OpenStorage(dsn)
+	if err != nil {
+		t.Fatalf("open postgres storage: %v", err)
+	}
+	repos["postgres"] = pgRepo
+
+	return repos
+}
+
+func TestUserRepository_CreateAndGet(t *testing.T) {
+	ctx := context.Background()
+
+	for name, repo := range repoBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			user := User{Email: "jane@example.com", Password: "s3cret!", Name: "Jane", Age: 30}
+
+			if err := repo.CreateUser(ctx, user); err != nil {
+				t.Fatalf("CreateUser: %v", err)
+			}
+
+			got, err := repo.GetByEmail(ctx, user.Email)
+			if err != nil {
+				t.Fatalf("GetByEmail: %v", err)
+			}
+			if got.Email != user.Email || got.Name != user.Name {
+				t.Fatalf("got %+v, want email/name matching %+v", got, user)
+			}
+			if got.Password == user.Password {
+				t.Fatalf("password was not hashed")
+			}
+		})
+	}
+}
+
+func TestUserRepository_DuplicateEmailRejected(t *testing.T) {
+	ctx := context.Background()
+
+	for name, repo := range repoBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			user := User{Email: "dup@example.com", Password: "s3cret!", Name: "Dup", Age: 25}
+
+			if err := repo.CreateUser(ctx, user); err != nil {
+				t.Fatalf("CreateUser: %v", err)
+			}
+			if err := repo.CreateUser(ctx, user); err == nil {
+				t.Fatalf("expected duplicate email to be rejected")
+			}
+		})
+	}
+}
+
+func TestUserRepository_UpdatePassword(t *testing.T) {
+	ctx := context.Background()
+
+	for name, repo := range repoBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			user := User{Email: "rehash@example.com", Password: "s3cret!", Name: "Rehash", Age: 22}
+
+			if err := repo.CreateUser(ctx, user); err != nil {
+				t.Fatalf("CreateUser: %v", err)
+			}
+			if err := repo.UpdatePassword(ctx, user.Email, "new-hash"); err != nil {
+				t.Fatalf("UpdatePassword: %v", err)
+			}
+
+			got, err := repo.GetByEmail(ctx, user.Email)
+			if err != nil {
+				t.Fatalf("GetByEmail: %v", err)
+			}
+			if got.Password != "new-hash" {
+				t.Fatalf("got password %q, want %q", got.Password, "new-hash")
+			}
+		})
+	}
+}
+
+func TestUserRepository_ListPaginated(t *testing.T) {
+	ctx := context.Background()
+
+	for name, repo := range repoBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 3; i++ {
+				user := User{
+					Email:    fmt.Sprintf("page%d@example.com", i),
+					Password: "s3cret!",
+					Name:     fmt.Sprintf("Page %d", i),
+					Age:      20,
+				}
+				if err := repo.CreateUser(ctx, user); err != nil {
+					t.Fatalf("CreateUser: %v", err)
+				}
+			}
+
+			got, err := repo.ListPaginated(ctx, 0, 0)
+			if err != nil {
+				t.Fatalf("ListPaginated: %v", err)
+			}
+			if len(got) != 0 {
+				t.Fatalf("limit=0 should return no rows, got %d", len(got))
+			}
+
+			got, err = repo.ListPaginated(ctx, 2, 0)
+			if err != nil {
+				t.Fatalf("ListPaginated: %v", err)
+			}
+			if len(got) != 2 {
+				t.Fatalf("limit=2 should return 2 rows, got %d", len(got))
+			}
+		})
+	}
+}